@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	usersCollectionName string = "users"
+	accessTokenTTL             = 15 * time.Minute
+	refreshTokenTTL            = 7 * 24 * time.Hour
+)
+
+type ctxKey string
+
+const userIDCtxKey ctxKey = "userID"
+
+var (
+	usersDB   *mongo.Collection
+	jwtSecret []byte
+
+	errMissingToken = errors.New("authorization token is missing")
+	errInvalidToken = errors.New("authorization token is invalid or expired")
+)
+
+type (
+	userModel struct {
+		ID           primitive.ObjectID `bson:"_id,omitempty"`
+		Email        string             `bson:"email"`
+		PasswordHash string             `bson:"passwordHash"`
+		CreatedAt    time.Time          `bson:"createdAt"`
+	}
+
+	credentials struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	authResponse struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresAt    string `json:"expires_at"`
+	}
+
+	refreshRequest struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	tokenClaims struct {
+		UserID    string `json:"user_id"`
+		TokenType string `json:"token_type"`
+		jwt.RegisteredClaims
+	}
+)
+
+func initAuth() {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		log.Fatal("Set your 'JWT_SECRET' environment variable")
+	}
+	jwtSecret = []byte(secret)
+	usersDB = client.Database(dbName).Collection(usersCollectionName)
+}
+
+func registerHandler(w http.ResponseWriter, r *http.Request) {
+	var c credentials
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_body", "Invalid request body", err.Error())
+		return
+	}
+	if c.Email == "" || c.Password == "" {
+		writeError(w, r, http.StatusBadRequest, "invalid_credentials", "Email and password are required", nil)
+		return
+	}
+
+	existing := usersDB.FindOne(context.TODO(), bson.M{"email": c.Email})
+	if existing.Err() == nil {
+		writeError(w, r, http.StatusConflict, "email_taken", "A user with this email already exists", nil)
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(c.Password), bcrypt.DefaultCost)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "hash_failed", "Failed to hash password", err.Error())
+		return
+	}
+
+	u := userModel{
+		ID:           primitive.NewObjectID(),
+		Email:        c.Email,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now(),
+	}
+	if _, err := usersDB.InsertOne(context.TODO(), u); err != nil {
+		// The FindOne check above can race with a concurrent registration for
+		// the same email; the unique index on users.email is what actually
+		// prevents the duplicate, so surface that as the same 409 the check
+		// above would have returned had it lost the race.
+		if mongo.IsDuplicateKeyError(err) {
+			writeError(w, r, http.StatusConflict, "email_taken", "A user with this email already exists", nil)
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "create_user_failed", "Failed to create user", err.Error())
+		return
+	}
+
+	resp, err := issueTokenPair(u.ID.Hex())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "token_issue_failed", "Failed to issue token", err.Error())
+		return
+	}
+	rnd.JSON(w, http.StatusCreated, resp)
+}
+
+func loginHandler(w http.ResponseWriter, r *http.Request) {
+	var c credentials
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_body", "Invalid request body", err.Error())
+		return
+	}
+
+	var u userModel
+	if err := usersDB.FindOne(context.TODO(), bson.M{"email": c.Email}).Decode(&u); err != nil {
+		writeError(w, r, http.StatusUnauthorized, "invalid_credentials", "Invalid email or password", nil)
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(c.Password)); err != nil {
+		writeError(w, r, http.StatusUnauthorized, "invalid_credentials", "Invalid email or password", nil)
+		return
+	}
+
+	resp, err := issueTokenPair(u.ID.Hex())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "token_issue_failed", "Failed to issue token", err.Error())
+		return
+	}
+	rnd.JSON(w, http.StatusOK, resp)
+}
+
+func refreshHandler(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_body", "Invalid request body", err.Error())
+		return
+	}
+
+	claims, err := parseToken(req.RefreshToken)
+	if err != nil || claims.TokenType != "refresh" {
+		writeError(w, r, http.StatusUnauthorized, "invalid_refresh_token", "Invalid or expired refresh token", nil)
+		return
+	}
+
+	resp, err := issueTokenPair(claims.UserID)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "token_issue_failed", "Failed to issue token", err.Error())
+		return
+	}
+	rnd.JSON(w, http.StatusOK, resp)
+}
+
+func issueTokenPair(userID string) (authResponse, error) {
+	accessExp := time.Now().Add(accessTokenTTL)
+	access, err := signToken(userID, "access", accessExp)
+	if err != nil {
+		return authResponse{}, err
+	}
+	refresh, err := signToken(userID, "refresh", time.Now().Add(refreshTokenTTL))
+	if err != nil {
+		return authResponse{}, err
+	}
+	return authResponse{
+		Token:        access,
+		RefreshToken: refresh,
+		ExpiresAt:    accessExp.Format(time.RFC3339),
+	}, nil
+}
+
+func signToken(userID, tokenType string, expiresAt time.Time) (string, error) {
+	claims := tokenClaims{
+		UserID:    userID,
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+func parseToken(raw string) (*tokenClaims, error) {
+	claims := &tokenClaims{}
+	token, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errInvalidToken
+		}
+		return jwtSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errInvalidToken
+	}
+	return claims, nil
+}
+
+// authMiddleware validates the `Authorization: Bearer <token>` header on
+// incoming requests and injects the authenticated user's ID into the
+// request context, rejecting the request with 401 otherwise.
+func authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			writeError(w, r, http.StatusUnauthorized, "missing_token", errMissingToken.Error(), nil)
+			return
+		}
+		parts := strings.SplitN(header, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+			writeError(w, r, http.StatusUnauthorized, "invalid_token", errInvalidToken.Error(), nil)
+			return
+		}
+
+		claims, err := parseToken(strings.TrimSpace(parts[1]))
+		if err != nil || claims.TokenType != "access" {
+			writeError(w, r, http.StatusUnauthorized, "invalid_token", errInvalidToken.Error(), nil)
+			return
+		}
+
+		if holder, ok := r.Context().Value(userIDHolderCtxKey{}).(*userIDHolder); ok {
+			holder.id = claims.UserID
+		}
+
+		ctx := context.WithValue(r.Context(), userIDCtxKey, claims.UserID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func userIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(userIDCtxKey).(string)
+	return id, ok
+}
+
+func authHandler() http.Handler {
+	rg := chi.NewRouter()
+	rg.Post("/register", registerHandler)
+	rg.Post("/login", loginHandler)
+	rg.Post("/refresh", refreshHandler)
+	return rg
+}
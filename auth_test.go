@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func init() {
+	jwtSecret = []byte("test-secret")
+}
+
+func TestAuthMiddleware_MissingHeader(t *testing.T) {
+	rr := callWithAuthHeader(t, "")
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rr.Code)
+	}
+}
+
+func TestAuthMiddleware_MalformedHeader(t *testing.T) {
+	rr := callWithAuthHeader(t, "Token abc123")
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rr.Code)
+	}
+}
+
+func TestAuthMiddleware_InvalidToken(t *testing.T) {
+	rr := callWithAuthHeader(t, "Bearer not-a-real-token")
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rr.Code)
+	}
+}
+
+func TestAuthMiddleware_ExpiredToken(t *testing.T) {
+	token, err := signToken("user-1", "access", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("signToken: %v", err)
+	}
+	rr := callWithAuthHeader(t, "Bearer "+token)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rr.Code)
+	}
+}
+
+func TestAuthMiddleware_ValidToken(t *testing.T) {
+	token, err := signToken("user-1", "access", time.Now().Add(accessTokenTTL))
+	if err != nil {
+		t.Fatalf("signToken: %v", err)
+	}
+
+	var gotUserID string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserID, _ = userIDFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	authMiddleware(next).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if gotUserID != "user-1" {
+		t.Fatalf("expected userID %q, got %q", "user-1", gotUserID)
+	}
+}
+
+func TestAuthMiddleware_RejectsRefreshToken(t *testing.T) {
+	token, err := signToken("user-1", "refresh", time.Now().Add(refreshTokenTTL))
+	if err != nil {
+		t.Fatalf("signToken: %v", err)
+	}
+	rr := callWithAuthHeader(t, "Bearer "+token)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rr.Code)
+	}
+}
+
+func callWithAuthHeader(t *testing.T, header string) *httptest.ResponseRecorder {
+	t.Helper()
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+	if header != "" {
+		req.Header.Set("Authorization", header)
+	}
+	authMiddleware(next).ServeHTTP(rr, req)
+	return rr
+}
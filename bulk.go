@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/thedevsaddam/renderer"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// bulkItemResult reports the outcome of a single item within a bulk
+// request, keyed by its position in the request payload.
+type bulkItemResult struct {
+	Index   int    `json:"index"`
+	ID      string `json:"id,omitempty"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+type bulkPatchItem struct {
+	ID        string     `json:"id"`
+	Title     *string    `json:"title,omitempty"`
+	Body      *string    `json:"body,omitempty"`
+	Completed *bool      `json:"completed,omitempty"`
+	Priority  *string    `json:"priority,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+	DueAt     *time.Time `json:"due_at,omitempty"`
+}
+
+type bulkDeleteRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// withTransaction runs fn inside a Mongo session transaction so that
+// partial failures across a batch of writes roll back together.
+func withTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) (interface{}, error)) error {
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, fn)
+	return err
+}
+
+// existingTodoIDs returns the subset of oids that currently exist and
+// belong to userID, so bulk update/delete can report "not found" for ids
+// a BulkWrite/DeleteMany would otherwise silently match zero documents for.
+func existingTodoIDs(ctx context.Context, userID string, oids []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	cursor, err := db.Find(
+		ctx,
+		bson.M{"_id": bson.M{"$in": oids}, "userId": userID},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	existing := make(map[primitive.ObjectID]bool, len(oids))
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		existing[doc.ID] = true
+	}
+	return existing, nil
+}
+
+// bulkCreateTodos validates each item independently, then inserts every
+// valid item in a single transaction so the batch either fully lands or
+// fully rolls back.
+func bulkCreateTodos(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+
+	var items []todo
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_body", "Failed to parse request body", err.Error())
+		return
+	}
+	if len(items) == 0 {
+		writeError(w, r, http.StatusBadRequest, "empty_batch", "At least one todo is required", nil)
+		return
+	}
+
+	now := time.Now()
+	results := make([]bulkItemResult, len(items))
+	models := make([]todoModel, 0, len(items))
+	validIdx := make([]int, 0, len(items))
+
+	for i, t := range items {
+		if t.Title == "" {
+			results[i] = bulkItemResult{Index: i, Error: "The title is required"}
+			continue
+		}
+		if t.Priority == "" {
+			t.Priority = string(priorityMedium)
+		} else if !isValidPriority(t.Priority) {
+			results[i] = bulkItemResult{Index: i, Error: "The priority must be one of low, medium, high"}
+			continue
+		}
+		if t.DueAt != nil && !t.DueAt.After(now) {
+			results[i] = bulkItemResult{Index: i, Error: "The due_at must be in the future"}
+			continue
+		}
+
+		models = append(models, todoModel{
+			ID:        primitive.NewObjectID(),
+			UserID:    userID,
+			Title:     t.Title,
+			Body:      t.Body,
+			Priority:  t.Priority,
+			Tags:      t.Tags,
+			DueAt:     t.DueAt,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		validIdx = append(validIdx, i)
+	}
+
+	if len(models) > 0 {
+		docs := make([]interface{}, len(models))
+		for i, m := range models {
+			docs[i] = m
+		}
+
+		err := withTransaction(r.Context(), func(sessCtx mongo.SessionContext) (interface{}, error) {
+			return db.InsertMany(sessCtx, docs)
+		})
+		for k, i := range validIdx {
+			if err != nil {
+				results[i] = bulkItemResult{Index: i, Error: err.Error()}
+				continue
+			}
+			results[i] = bulkItemResult{Index: i, ID: models[k].ID.Hex(), Success: true}
+		}
+	}
+
+	rnd.JSON(w, http.StatusMultiStatus, renderer.M{"results": results})
+}
+
+// bulkUpdateTodos applies a patch per item via a single BulkWrite of
+// UpdateOneModels, wrapped in a transaction.
+func bulkUpdateTodos(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+
+	var items []bulkPatchItem
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_body", "Failed to parse request body", err.Error())
+		return
+	}
+	if len(items) == 0 {
+		writeError(w, r, http.StatusBadRequest, "empty_batch", "At least one patch is required", nil)
+		return
+	}
+
+	results := make([]bulkItemResult, len(items))
+	oidByIdx := make(map[int]primitive.ObjectID, len(items))
+
+	for i, it := range items {
+		oid, err := primitive.ObjectIDFromHex(it.ID)
+		if err != nil {
+			results[i] = bulkItemResult{Index: i, Error: "The id is invalid"}
+			continue
+		}
+		if it.Priority != nil && !isValidPriority(*it.Priority) {
+			results[i] = bulkItemResult{Index: i, Error: "The priority must be one of low, medium, high"}
+			continue
+		}
+		oidByIdx[i] = oid
+	}
+
+	candidates := make([]primitive.ObjectID, 0, len(oidByIdx))
+	for _, oid := range oidByIdx {
+		candidates = append(candidates, oid)
+	}
+
+	if len(candidates) > 0 {
+		// The existence check and the BulkWrite run inside the same
+		// transaction, so a todo deleted between the two is caught as a
+		// write conflict and retried rather than silently reported as
+		// updated: on retry the check simply sees it's gone.
+		notFound := make(map[primitive.ObjectID]bool, len(oidByIdx))
+		err := withTransaction(r.Context(), func(sessCtx mongo.SessionContext) (interface{}, error) {
+			existing, err := existingTodoIDs(sessCtx, userID, candidates)
+			if err != nil {
+				return nil, err
+			}
+
+			writes := make([]mongo.WriteModel, 0, len(oidByIdx))
+			for i, oid := range oidByIdx {
+				if !existing[oid] {
+					notFound[oid] = true
+					continue
+				}
+
+				it := items[i]
+				set := bson.M{"updatedAt": time.Now()}
+				if it.Title != nil {
+					set["title"] = *it.Title
+				}
+				if it.Body != nil {
+					set["body"] = *it.Body
+				}
+				if it.Completed != nil {
+					set["completed"] = *it.Completed
+				}
+				if it.Priority != nil {
+					set["priority"] = *it.Priority
+				}
+				if it.Tags != nil {
+					set["tags"] = it.Tags
+				}
+				if it.DueAt != nil {
+					set["dueAt"] = it.DueAt
+				}
+
+				writes = append(writes, mongo.NewUpdateOneModel().
+					SetFilter(bson.M{"_id": oid, "userId": userID}).
+					SetUpdate(bson.M{"$set": set}))
+			}
+			if len(writes) == 0 {
+				return nil, nil
+			}
+			return db.BulkWrite(sessCtx, writes)
+		})
+
+		for i, oid := range oidByIdx {
+			switch {
+			case notFound[oid]:
+				results[i] = bulkItemResult{Index: i, ID: items[i].ID, Error: "todo not found"}
+			case err != nil:
+				results[i] = bulkItemResult{Index: i, ID: items[i].ID, Error: err.Error()}
+			default:
+				results[i] = bulkItemResult{Index: i, ID: items[i].ID, Success: true}
+			}
+		}
+	}
+
+	rnd.JSON(w, http.StatusMultiStatus, renderer.M{"results": results})
+}
+
+// bulkDeleteTodos deletes every matching id in one DeleteMany, wrapped in a
+// transaction alongside the rest of the bulk endpoints.
+func bulkDeleteTodos(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+
+	var req bulkDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_body", "Failed to parse request body", err.Error())
+		return
+	}
+	if len(req.IDs) == 0 {
+		writeError(w, r, http.StatusBadRequest, "empty_batch", "At least one id is required", nil)
+		return
+	}
+
+	results := make([]bulkItemResult, len(req.IDs))
+	oidByIdx := make(map[int]primitive.ObjectID, len(req.IDs))
+
+	for i, idStr := range req.IDs {
+		oid, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			results[i] = bulkItemResult{Index: i, Error: "The id is invalid"}
+			continue
+		}
+		oidByIdx[i] = oid
+	}
+
+	candidates := make([]primitive.ObjectID, 0, len(oidByIdx))
+	for _, oid := range oidByIdx {
+		candidates = append(candidates, oid)
+	}
+
+	if len(candidates) > 0 {
+		// Same reasoning as bulkUpdateTodos: check and delete share a
+		// transaction so a concurrent delete of the same id is resolved by
+		// MongoDB's write-conflict retry rather than a stale pre-check.
+		notFound := make(map[primitive.ObjectID]bool, len(oidByIdx))
+		err := withTransaction(r.Context(), func(sessCtx mongo.SessionContext) (interface{}, error) {
+			existing, err := existingTodoIDs(sessCtx, userID, candidates)
+			if err != nil {
+				return nil, err
+			}
+
+			oids := make([]primitive.ObjectID, 0, len(oidByIdx))
+			for _, oid := range oidByIdx {
+				if !existing[oid] {
+					notFound[oid] = true
+					continue
+				}
+				oids = append(oids, oid)
+			}
+			if len(oids) == 0 {
+				return nil, nil
+			}
+			return db.DeleteMany(sessCtx, bson.M{"_id": bson.M{"$in": oids}, "userId": userID})
+		})
+
+		for i, oid := range oidByIdx {
+			switch {
+			case notFound[oid]:
+				results[i] = bulkItemResult{Index: i, ID: req.IDs[i], Error: "todo not found"}
+			case err != nil:
+				results[i] = bulkItemResult{Index: i, ID: req.IDs[i], Error: err.Error()}
+			default:
+				results[i] = bulkItemResult{Index: i, ID: req.IDs[i], Success: true}
+			}
+		}
+	}
+
+	rnd.JSON(w, http.StatusMultiStatus, renderer.M{"results": results})
+}
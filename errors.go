@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// APIError is the structured body returned for every non-2xx JSON response,
+// carrying the request ID so a client report can be matched back to a log line.
+type APIError struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// writeError writes a structured APIError, stamping it with the request ID
+// chi's RequestID middleware attached to the request context.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string, details interface{}) {
+	rnd.JSON(w, status, APIError{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: middleware.GetReqID(r.Context()),
+	})
+}
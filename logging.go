@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/rs/zerolog"
+)
+
+var logger = zerolog.New(os.Stdout).With().Timestamp().Logger()
+
+type userIDHolderCtxKey struct{}
+
+// userIDHolder is threaded through the request context by requestLogger and
+// filled in by authMiddleware further down the chain. A plain context value
+// wouldn't work here: authMiddleware's updated context is only visible to
+// handlers downstream of it, not to requestLogger's own request variable
+// once next.ServeHTTP returns. The holder gives authMiddleware a mutable
+// cell that requestLogger can still read after the chain unwinds.
+type userIDHolder struct {
+	id string
+}
+
+// requestLogger logs method/path/status/latency/user-id for every request,
+// tagged with the request ID chi's RequestID middleware assigned upstream.
+func requestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		holder := &userIDHolder{}
+		ctx := context.WithValue(r.Context(), userIDHolderCtxKey{}, holder)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		logger.Info().
+			Str("request_id", middleware.GetReqID(r.Context())).
+			Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Int("status", ww.Status()).
+			Dur("latency", time.Since(start)).
+			Str("user_id", holder.id).
+			Msg("request handled")
+	})
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -15,9 +16,9 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/thedevsaddam/renderer"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	bs "gopkg.in/mgo.v2/bson"
 )
 
 var rnd *renderer.Render
@@ -30,22 +31,59 @@ const (
 	port           string = ":9000"
 )
 
+type priority string
+
+const (
+	priorityLow    priority = "low"
+	priorityMedium priority = "medium"
+	priorityHigh   priority = "high"
+)
+
+func isValidPriority(p string) bool {
+	switch priority(p) {
+	case priorityLow, priorityMedium, priorityHigh:
+		return true
+	default:
+		return false
+	}
+}
+
 type (
 	todoModel struct {
-		ID        bs.ObjectId `bson:"_id,omitempty"`
-		Title     string      `bson:"title"`
-		Completed bool        `bson:"completed"`
-		CreatedAt time.Time   `bson:"createdAt"`
+		ID        primitive.ObjectID `bson:"_id,omitempty"`
+		UserID    string             `bson:"userId"`
+		Title     string             `bson:"title"`
+		Body      string             `bson:"body"`
+		Completed bool               `bson:"completed"`
+		Priority  string             `bson:"priority"`
+		Tags      []string           `bson:"tags"`
+		DueAt     *time.Time         `bson:"dueAt,omitempty"`
+		CreatedAt time.Time          `bson:"createdAt"`
+		UpdatedAt time.Time          `bson:"updatedAt"`
 	}
 	todo struct {
-		ID        string `json:"id"`
-		Title     string `json:"title"`
-		Completed bool   `json:"completed"`
-		CreatedAt string `json:"created_at"`
+		ID        string     `json:"id"`
+		Title     string     `json:"title"`
+		Body      string     `json:"body"`
+		Completed bool       `json:"completed"`
+		Priority  string     `json:"priority"`
+		Tags      []string   `json:"tags"`
+		DueAt     *time.Time `json:"due_at,omitempty"`
+		CreatedAt string     `json:"created_at"`
+		UpdatedAt string     `json:"updated_at"`
 	}
 )
 
 func init() {
+	rnd = renderer.New()
+}
+
+// setup connects to MongoDB and wires up everything that depends on a live
+// connection. It's called from main() rather than package init() so that
+// `go test` (which never calls main()) can exercise pure-logic units like
+// the auth middleware without requiring MONGO_URI/JWT_SECRET or a reachable
+// MongoDB instance.
+func setup() {
 	log.Println("Initializing server...")
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found")
@@ -64,11 +102,18 @@ func init() {
 		panic(err)
 	}
 
-	rnd = renderer.New()
 	log.Println("Renderer Initialised")
 
 	db = client.Database(dbName).Collection("todo")
 	log.Println("Server Initialised!")
+
+	initAuth()
+	log.Println("Auth Initialised!")
+
+	if err := ensureIndexes(); err != nil {
+		log.Fatal("Failed to ensure indexes: ", err)
+	}
+	log.Println("Indexes Ensured!")
 }
 
 func checkErr(err error) {
@@ -79,40 +124,60 @@ func checkErr(err error) {
 
 func todoHandler() http.Handler {
 	rg := chi.NewRouter()
+	rg.Use(authMiddleware)
 	rg.Group(func(r chi.Router) {
 		r.Get("/", fetchTodo)
+		r.Get("/stream", streamTodos)
 		r.Post("/", createTodo)
 		r.Put("/{id}", updateTodo)
 		r.Delete("/{id}", deleteTodo)
+		r.Post("/bulk", bulkCreateTodos)
+		r.Patch("/bulk", bulkUpdateTodos)
+		r.Delete("/bulk", bulkDeleteTodos)
 	})
 	return rg
 }
 
 func createTodo(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+
 	var t todo
 
 	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
-		rnd.JSON(w, http.StatusProcessing, err)
+		writeError(w, r, http.StatusBadRequest, "invalid_body", "Failed to parse request body", err.Error())
 		return
 	}
 	if t.Title == "" {
-		rnd.JSON(w, http.StatusBadRequest, renderer.M{
-			"message": "The title is required",
-		})
+		writeError(w, r, http.StatusBadRequest, "invalid_title", "The title is required", nil)
+		return
+	}
+	if t.Priority == "" {
+		t.Priority = string(priorityMedium)
+	} else if !isValidPriority(t.Priority) {
+		writeError(w, r, http.StatusBadRequest, "invalid_priority", "The priority must be one of low, medium, high", nil)
+		return
+	}
+	if t.DueAt != nil && !t.DueAt.After(time.Now()) {
+		writeError(w, r, http.StatusBadRequest, "invalid_due_at", "The due_at must be in the future", nil)
 		return
 	}
+
+	now := time.Now()
 	tm := todoModel{
-		ID:        bs.NewObjectId(),
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
 		Title:     t.Title,
+		Body:      t.Body,
 		Completed: false,
-		CreatedAt: time.Now(),
+		Priority:  t.Priority,
+		Tags:      t.Tags,
+		DueAt:     t.DueAt,
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
 	resp, err := db.InsertOne(context.TODO(), tm)
 	if err != nil {
-		rnd.JSON(w, http.StatusProcessing, renderer.M{
-			"message": "Failed to save todo",
-			"error":   err,
-		})
+		writeError(w, r, http.StatusInternalServerError, "insert_failed", "Failed to save todo", err.Error())
 		return
 	}
 	rnd.JSON(
@@ -127,19 +192,17 @@ func createTodo(w http.ResponseWriter, r *http.Request) {
 }
 
 func deleteTodo(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+
 	id := strings.TrimSpace(chi.URLParam(r, "id"))
-	if !bs.IsObjectIdHex(id) {
-		rnd.JSON(w, http.StatusBadRequest, renderer.M{
-			"message": "The id is invalid",
-		})
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_id", "The id is invalid", nil)
 		return
 	}
-	resp, err := db.DeleteOne(context.TODO(), bs.ObjectIdHex(id))
+	resp, err := db.DeleteOne(context.TODO(), bson.M{"_id": oid, "userId": userID})
 	if err != nil {
-		rnd.JSON(w, http.StatusProcessing, renderer.M{
-			"message": "Failed to delete todo",
-			"error":   err,
-		})
+		writeError(w, r, http.StatusInternalServerError, "delete_failed", "Failed to delete todo", err.Error())
 		return
 	}
 
@@ -150,78 +213,180 @@ func deleteTodo(w http.ResponseWriter, r *http.Request) {
 }
 
 func fetchTodo(w http.ResponseWriter, r *http.Request) {
-	cursor, err := db.Find(context.TODO(), bson.M{})
+	userID, _ := userIDFromContext(r.Context())
+
+	filter, err := buildTodoFilter(r, userID)
 	if err != nil {
-		rnd.JSON(w, http.StatusProcessing, renderer.M{
-			"message": "Failed to fetch todo",
-			"error":   err,
-		})
+		writeError(w, r, http.StatusBadRequest, "invalid_filter", "Invalid filter parameters", err.Error())
 		return
 	}
-	defer cursor.Close(context.TODO())
 
-	todos := []todoModel{}
-	for cursor.Next(context.TODO()) {
-		var t todoModel
-		if err := cursor.Decode(&t); err != nil {
-			rnd.JSON(w, http.StatusProcessing, renderer.M{
-				"message": "Failed to decode todo",
-				"error":   err,
-			})
-			return
-		}
-		todos = append(todos, t)
+	q := r.URL.Query()
+	var (
+		todos      []todoModel
+		nextCursor string
+	)
+	if cursorParam := q.Get("cursor"); cursorParam != "" || q.Get("limit") != "" {
+		todos, nextCursor, err = fetchTodoByCursor(filter, cursorParam, q.Get("limit"))
+	} else {
+		todos, err = fetchTodoByPage(filter, buildTodoSort(r), q.Get("page"), q.Get("page_size"))
+	}
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_pagination", "Failed to fetch todo", err.Error())
+		return
 	}
 
 	todoList := []todo{}
 	for _, t := range todos {
-		todoList = append(todoList, todo{
-			ID:        t.ID.Hex(),
-			Title:     t.Title,
-			Completed: t.Completed,
-			CreatedAt: t.CreatedAt.Format(time.RFC3339),
-		})
+		todoList = append(todoList, toPublicTodo(t))
+	}
+	resp := renderer.M{
+		"data":        todoList,
+		"next_cursor": nextCursor,
+	}
+
+	// total is opt-in: CountDocuments is a full collection scan under the
+	// same filter, so it's only worth paying for when a caller asks for it.
+	if q.Get("include_total") == "true" {
+		total, err := db.CountDocuments(context.TODO(), filter)
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, "count_failed", "Failed to count todo", err.Error())
+			return
+		}
+		resp["total"] = total
 	}
-	rnd.JSON(w, http.StatusOK, renderer.M{
-		"data": todoList,
-	})
 
+	rnd.JSON(w, http.StatusOK, resp)
+}
+
+// toPublicTodo converts a stored todoModel into its public JSON representation.
+func toPublicTodo(t todoModel) todo {
+	return todo{
+		ID:        t.ID.Hex(),
+		Title:     t.Title,
+		Body:      t.Body,
+		Completed: t.Completed,
+		Priority:  t.Priority,
+		Tags:      t.Tags,
+		DueAt:     t.DueAt,
+		CreatedAt: t.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: t.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// buildTodoFilter translates the fetchTodo query-string parameters
+// (`completed`, `tag`, `priority`, `due_before`, `q`) into a Mongo filter
+// scoped to the authenticated user.
+func buildTodoFilter(r *http.Request, userID string) (bson.M, error) {
+	filter := bson.M{"userId": userID}
+	q := r.URL.Query()
+
+	if v := q.Get("completed"); v != "" {
+		filter["completed"] = v == "true"
+	}
+	if v := q.Get("tag"); v != "" {
+		filter["tags"] = v
+	}
+	if v := q.Get("priority"); v != "" {
+		if !isValidPriority(v) {
+			return nil, fmt.Errorf("invalid priority %q", v)
+		}
+		filter["priority"] = v
+	}
+	if v := q.Get("due_before"); v != "" {
+		dueBefore, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid due_before: %w", err)
+		}
+		filter["dueAt"] = bson.M{"$lt": dueBefore}
+	}
+	if v := q.Get("q"); v != "" {
+		filter["$text"] = bson.M{"$search": v}
+	}
+
+	return filter, nil
+}
+
+// buildTodoSort translates `?sort=field:direction` (e.g. `due_at:asc`) into
+// a Mongo sort document, defaulting to newest-first by createdAt.
+func buildTodoSort(r *http.Request) bson.D {
+	sortParam := r.URL.Query().Get("sort")
+	if sortParam == "" {
+		return bson.D{{Key: "createdAt", Value: -1}}
+	}
+
+	field, direction := sortParam, "asc"
+	if idx := strings.Index(sortParam, ":"); idx != -1 {
+		field, direction = sortParam[:idx], sortParam[idx+1:]
+	}
+
+	fieldMap := map[string]string{
+		"due_at":     "dueAt",
+		"created_at": "createdAt",
+		"updated_at": "updatedAt",
+		"priority":   "priority",
+		"title":      "title",
+	}
+	mongoField, ok := fieldMap[field]
+	if !ok {
+		mongoField = "createdAt"
+	}
+
+	order := 1
+	if direction == "desc" {
+		order = -1
+	}
+	return bson.D{{Key: mongoField, Value: order}}
 }
 
 func updateTodo(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+
 	id := strings.TrimSpace(chi.URLParam(r, "id"))
 
-	if !bs.IsObjectIdHex(id) {
-		rnd.JSON(
-			w,
-			http.StatusBadRequest,
-			renderer.M{
-				"message": "The id is invalid",
-			},
-		)
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_id", "The id is invalid", nil)
 		return
 	}
 
 	var t todo
 
 	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
-		rnd.JSON(w, http.StatusProcessing, err)
+		writeError(w, r, http.StatusBadRequest, "invalid_body", "Failed to parse request body", err.Error())
 		return
 	}
 	if t.Title == "" {
-		rnd.JSON(w, http.StatusBadRequest, renderer.M{
-			"message": "The title field id is required",
-		})
+		writeError(w, r, http.StatusBadRequest, "invalid_title", "The title field id is required", nil)
+		return
+	}
+	if t.Priority == "" {
+		t.Priority = string(priorityMedium)
+	} else if !isValidPriority(t.Priority) {
+		writeError(w, r, http.StatusBadRequest, "invalid_priority", "The priority must be one of low, medium, high", nil)
 		return
 	}
 
-	cursor, err := db.UpdateByID(context.TODO(), bs.ObjectIdHex(id), bson.M{"$set": bson.M{"title": t.Title, "completed": t.Completed}})
-
+	cursor, err := db.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": oid, "userId": userID},
+		bson.M{"$set": bson.M{
+			"title":     t.Title,
+			"body":      t.Body,
+			"completed": t.Completed,
+			"priority":  t.Priority,
+			"tags":      t.Tags,
+			"dueAt":     t.DueAt,
+			"updatedAt": time.Now(),
+		}},
+	)
 	if err != nil {
-		rnd.JSON(w, http.StatusInternalServerError, renderer.M{
-			"message": "Error while updating task",
-			"error":   err,
-		})
+		writeError(w, r, http.StatusInternalServerError, "update_failed", "Error while updating task", err.Error())
+		return
+	}
+	if cursor.MatchedCount == 0 {
+		writeError(w, r, http.StatusNotFound, "not_found", "todo not found", nil)
+		return
 	}
 	log.Println("Update cursor", cursor)
 	rnd.JSON(w, http.StatusOK, renderer.M{
@@ -235,11 +400,15 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func main() {
+	setup()
+
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	r.Use(middleware.RequestID)
+	r.Use(requestLogger)
 
 	// Define routes
 	r.Get("/", homeHandler)
+	r.Mount("/auth", authHandler())
 	r.Mount("/todo", todoHandler())
 
 	// Create the HTTP server
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ensureIndexes creates the indexes the todo collection is expected to
+// have, and is safe to call on every startup since CreateMany is a no-op
+// for indexes that already exist.
+//
+// There is deliberately no startup pass that rewrites `_id` fields for
+// documents from the old gopkg.in/mgo.v2/bson driver: mgo's bson.ObjectId
+// and the official driver's primitive.ObjectID both serialize to the same
+// 12-byte BSON ObjectId wire format (subtype 0x07), so every existing
+// document already decodes correctly as a primitive.ObjectID with no
+// rewrite needed.
+func ensureIndexes() error {
+	_, err := db.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "createdAt", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+		{Keys: bson.D{{Key: "title", Value: "text"}, {Key: "body", Value: "text"}}},
+	}, options.CreateIndexes())
+	if err != nil {
+		return err
+	}
+
+	_, err = usersDB.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Turns on changeStreamPreAndPostImages for the todo collection, which is
+	// what lets a delete change event carry the deleted document's userId via
+	// fullDocumentBeforeChange (streamTodos' SSE feed needs this since delete
+	// events have no fullDocument). Done once at startup alongside the rest
+	// of the schema setup rather than lazily on the first SSE request, so a
+	// Mongo role without collMod privilege fails fast at boot instead of on
+	// every streaming connection attempt.
+	return client.Database(dbName).RunCommand(context.TODO(), bson.D{
+		{Key: "collMod", Value: collectionName},
+		{Key: "changeStreamPreAndPostImages", Value: bson.M{"enabled": true}},
+	}).Err()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultPageSize = 20
+	defaultLimit    = 20
+)
+
+type cursorToken struct {
+	ID        primitive.ObjectID `json:"id"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// encodeCursor packs a todo's position into an opaque, URL-safe token.
+func encodeCursor(t todoModel) string {
+	raw, _ := json.Marshal(cursorToken{ID: t.ID, CreatedAt: t.CreatedAt})
+	return base64.URLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor unpacks a token produced by encodeCursor.
+func decodeCursor(token string) (cursorToken, error) {
+	var ct cursorToken
+	raw, err := base64.URLEncoding.DecodeString(token)
+	if err != nil {
+		return ct, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if err := json.Unmarshal(raw, &ct); err != nil {
+		return ct, fmt.Errorf("invalid cursor: %w", err)
+	}
+	return ct, nil
+}
+
+// fetchTodoByPage runs an offset/limit (`?page=1&page_size=20`) query
+// against the todo collection.
+func fetchTodoByPage(filter bson.M, sort bson.D, pageParam, pageSizeParam string) ([]todoModel, error) {
+	page, err := parsePositiveInt(pageParam, 1)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page: %w", err)
+	}
+	pageSize, err := parsePositiveInt(pageSizeParam, defaultPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page_size: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(sort).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	return findTodos(filter, opts)
+}
+
+// fetchTodoByCursor runs a cursor-mode (`?cursor=<token>&limit=20`) query,
+// translating the decoded cursor into a `bson.M{"_id": bson.M{"$gt": ...}}`
+// predicate sorted ascending by `_id`, and returns the token for the next page.
+func fetchTodoByCursor(filter bson.M, cursorParam, limitParam string) ([]todoModel, string, error) {
+	limit, err := parsePositiveInt(limitParam, defaultLimit)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid limit: %w", err)
+	}
+
+	if cursorParam != "" {
+		ct, err := decodeCursor(cursorParam)
+		if err != nil {
+			return nil, "", err
+		}
+		filter = mergeFilter(filter, bson.M{"_id": bson.M{"$gt": ct.ID}})
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	todos, err := findTodos(filter, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(todos) == int(limit) {
+		nextCursor = encodeCursor(todos[len(todos)-1])
+	}
+	return todos, nextCursor, nil
+}
+
+func findTodos(filter bson.M, opts *options.FindOptions) ([]todoModel, error) {
+	cursor, err := db.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	todos := []todoModel{}
+	for cursor.Next(context.TODO()) {
+		var t todoModel
+		if err := cursor.Decode(&t); err != nil {
+			return nil, err
+		}
+		todos = append(todos, t)
+	}
+	return todos, nil
+}
+
+func mergeFilter(base bson.M, extra bson.M) bson.M {
+	merged := bson.M{}
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+func parsePositiveInt(raw string, fallback int) (int, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("must be a positive integer, got %q", raw)
+	}
+	return n, nil
+}
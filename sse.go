@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/thedevsaddam/renderer"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const sseKeepAliveInterval = 15 * time.Second
+
+type changeEvent struct {
+	OperationType            string    `bson:"operationType"`
+	FullDocument             todoModel `bson:"fullDocument"`
+	FullDocumentBeforeChange todoModel `bson:"fullDocumentBeforeChange"`
+	DocumentKey              struct {
+		ID primitive.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+}
+
+// streamTodos upgrades the connection to text/event-stream and relays
+// create/update/delete events for the authenticated user's todos, sourced
+// from a MongoDB change stream on the todo collection.
+func streamTodos(w http.ResponseWriter, r *http.Request) {
+	userID, _ := userIDFromContext(r.Context())
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, http.StatusInternalServerError, "streaming_unsupported", "Streaming is not supported by this connection", nil)
+		return
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"fullDocument.userId": userID},
+			bson.M{"fullDocumentBeforeChange.userId": userID},
+		}}}},
+	}
+	stream, err := db.Watch(
+		r.Context(),
+		pipeline,
+		options.ChangeStream().
+			SetFullDocument(options.UpdateLookup).
+			SetFullDocumentBeforeChange(options.WhenAvailable),
+	)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "watch_failed", "Failed to open change stream", err.Error())
+		return
+	}
+	defer stream.Close(r.Context())
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		default:
+			if !stream.TryNext(ctx) {
+				if err := stream.Err(); err != nil {
+					return
+				}
+				time.Sleep(200 * time.Millisecond)
+				continue
+			}
+			var event changeEvent
+			if err := stream.Decode(&event); err != nil {
+				continue
+			}
+			writeTodoEvent(w, event)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeTodoEvent(w http.ResponseWriter, event changeEvent) {
+	var (
+		name    string
+		payload interface{}
+	)
+	switch event.OperationType {
+	case "insert":
+		name, payload = "todo.created", toPublicTodo(event.FullDocument)
+	case "update", "replace":
+		name, payload = "todo.updated", toPublicTodo(event.FullDocument)
+	case "delete":
+		name, payload = "todo.deleted", renderer.M{"id": event.DocumentKey.ID.Hex()}
+	default:
+		return
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+}